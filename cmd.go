@@ -25,11 +25,11 @@ var (
 Usage:
 
   {{.Name}} [flags] <command> [subcommand] [args]
-
-Available Commands:
+{{range .Groups}}
+{{.Title}}:
 {{range .Commands}}{{if .Runnable}}
   {{.Name | printf "%-11s"}} {{.Short}}{{end}}{{end}}
-
+{{end}}
 options:
 
   -v --verbose   make the operation more talkative
@@ -54,7 +54,58 @@ type Command struct {
 	SetFlags    func(f *flag.FlagSet)
 	SubCommands Commands
 
-	flag *flag.FlagSet
+	// PersistentFlags registers flags that are inherited by SubCommands in
+	// addition to whatever they register via their own SetFlags. Because
+	// they are parsed against the resolved leaf command's FlagSet, they
+	// must be given after the subcommand they are attached to, not before
+	// it: "mycli sub --config=foo bar --baz", not "mycli --config=foo sub
+	// bar --baz".
+	PersistentFlags func(f *flag.FlagSet)
+
+	// PersistentPreRun, PreRun, PostRun and PersistentPostRun are invoked
+	// around Run in the standard parent->child->parent order: ancestors'
+	// PersistentPreRun run root-first, then PreRun, then Run, then PostRun,
+	// then ancestors' PersistentPostRun run leaf-first. Any hook returning
+	// an error short-circuits the remaining hooks and Run.
+	PersistentPreRun  func(cmd *Command, args []string) error
+	PreRun            func(cmd *Command, args []string) error
+	PostRun           func(cmd *Command, args []string) error
+	PersistentPostRun func(cmd *Command, args []string) error
+
+	// ValidArgsFunction returns dynamic completions for this command's
+	// positional arguments, used by the completion command and Shell.
+	ValidArgsFunction func(cmd *Command, args []string, toComplete string) ([]string, ShellCompDirective)
+
+	// Hidden excludes the command from Usage listings and suggestions.
+	Hidden bool
+
+	// DisableSuggestions turns off "Did you mean" hints when a lookup
+	// among this command's SubCommands fails to match.
+	DisableSuggestions bool
+
+	// Group buckets this command under the title registered with
+	// AddGroup for that id, in Usage output. Commands with an unknown or
+	// empty Group fall back to an "Additional Commands" bucket.
+	Group string
+
+	flag   *flag.FlagSet
+	parent *Command
+}
+
+// ancestorChain returns the command chain from the root command down to c,
+// inclusive of c itself.
+func (c *Command) ancestorChain() []*Command {
+	var chain []*Command
+
+	for cur := c; cur != nil; cur = cur.parent {
+		chain = append(chain, cur)
+	}
+
+	for i, j := 0, len(chain)-1; i < j; i, j = i+1, j-1 {
+		chain[i], chain[j] = chain[j], chain[i]
+	}
+
+	return chain
 }
 
 // Usage u
@@ -71,28 +122,25 @@ func (c *Command) Usage() {
 		fmt.Fprintf(os.Stdout, "\n\n")
 	}
 
-	// Display subcommands if any
-	if len(c.SubCommands) > 0 {
-		fmt.Fprintf(os.Stdout, "Available Subcommands:\n")
-
+	// Display subcommands if any, bucketed by Group
+	if groups := groupedCommands(c.SubCommands); len(groups) > 0 {
 		maxLen := 0
 
 		for _, sub := range c.SubCommands {
-			if sub.Runnable() {
-				nameLen := len(sub.Name)
-				if nameLen > maxLen {
-					maxLen = nameLen
-				}
+			if sub.Runnable() && !sub.Hidden && len(sub.Name) > maxLen {
+				maxLen = len(sub.Name)
 			}
 		}
 
-		for _, sub := range c.SubCommands {
-			if sub.Runnable() {
+		for _, g := range groups {
+			fmt.Fprintf(os.Stdout, "%s:\n", g.Title)
+
+			for _, sub := range g.Commands {
 				fmt.Fprintf(os.Stdout, "  %-*s %s\n", maxLen+2, sub.Name, sub.Short)
 			}
-		}
 
-		fmt.Fprintf(os.Stdout, "\n")
+			fmt.Fprintf(os.Stdout, "\n")
+		}
 	}
 
 	if c.flag != nil {
@@ -153,12 +201,28 @@ func SetFlags(f func(f *flag.FlagSet)) {
 
 // AddCommands Add Command.
 func AddCommands(cmds ...*Command) {
+	for _, cmd := range cmds {
+		linkParents(cmd, nil)
+	}
 	_commands = append(_commands, cmds...)
 }
 
+// linkParents sets c.parent and recurses into c.SubCommands so that
+// PersistentFlags and the Persistent*Run hooks can walk the command tree.
+func linkParents(c *Command, parent *Command) {
+	c.parent = parent
+	for _, sub := range c.SubCommands {
+		linkParents(sub, c)
+	}
+}
+
 // Execute func
 func Execute() {
 
+	registerCompletionCommand()
+	registerShellCommand()
+	registerHelpCommand()
+
 	flag.Usage = usage
 	flag.Parse() // catch -h argument
 	log.SetFlags(0)
@@ -175,9 +239,10 @@ func Execute() {
 	}
 
 	name := args[0]
-	cmd, remainingArgs, err := findCommand(_commands, args)
+	cmd, remainingArgs, err := findCommand(_commands, args, nil)
 
 	if err != nil {
+		printSuggestions(err)
 		fatalf("cmd(%s): %v \n", name, err)
 	}
 
@@ -187,6 +252,12 @@ func Execute() {
 
 	addFlags(cmd.flag)
 
+	for _, ancestor := range cmd.ancestorChain() {
+		if ancestor.PersistentFlags != nil {
+			ancestor.PersistentFlags(cmd.flag)
+		}
+	}
+
 	if cmd.SetFlags != nil {
 		cmd.SetFlags(cmd.flag)
 	}
@@ -199,47 +270,142 @@ func Execute() {
 		fatalf("cmd(%s): %v \n", name, err)
 	}
 
-	if err := cmd.Run(cmd, cmd.flag.Args()); err != nil {
+	if err := runCommand(cmd, cmd.flag.Args()); err != nil {
 		fatalf("cmd(%s): %v\n", name, err)
 	}
 
 	exit()
 }
 
-// findCommand recursively finds a command or subcommand
-func findCommand(cmds Commands, args []string) (*Command, []string, error) {
-	if len(args) == 0 {
+// runCommand executes cmd.Run together with its PreRun/PostRun hooks and the
+// PersistentPreRun/PersistentPostRun hooks of its ancestor chain, in the
+// standard parent->child->parent order.
+func runCommand(cmd *Command, args []string) error {
+	if !cmd.Runnable() {
+		cmd.Usage()
+		return fmt.Errorf("cmd(%s): no Run defined for this command", cmd.Name)
+	}
+
+	chain := cmd.ancestorChain()
+
+	for _, ancestor := range chain {
+		if ancestor.PersistentPreRun != nil {
+			if err := ancestor.PersistentPreRun(cmd, args); err != nil {
+				return err
+			}
+		}
+	}
+
+	if cmd.PreRun != nil {
+		if err := cmd.PreRun(cmd, args); err != nil {
+			return err
+		}
+	}
+
+	if err := cmd.Run(cmd, args); err != nil {
+		return err
+	}
+
+	if cmd.PostRun != nil {
+		if err := cmd.PostRun(cmd, args); err != nil {
+			return err
+		}
+	}
+
+	for i := len(chain) - 1; i >= 0; i-- {
+		if chain[i].PersistentPostRun != nil {
+			if err := chain[i].PersistentPostRun(cmd, args); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// splitLeadingFlags scans args for the first token that does not look like
+// a flag, returning its value and index alongside the flag-looking tokens
+// seen before it. This lets findCommand resolve the next command token even
+// when an ancestor's PersistentFlags are interspersed before it, e.g.
+// "sub --config=foo bar": name is "bar", index is 1, flags is
+// ["--config=foo"].
+func splitLeadingFlags(args []string) (name string, index int, flags []string) {
+	index = -1
+
+	for i, a := range args {
+		if strings.HasPrefix(a, "-") {
+			flags = append(flags, a)
+			continue
+		}
+		name, index = a, i
+		break
+	}
+
+	return
+}
+
+// findCommand recursively finds a command or subcommand. parent is the
+// Command whose SubCommands is being searched, or nil at the top level.
+// Flag tokens may appear before the command name being resolved (they are
+// carried through to the remaining args for the eventual leaf's FlagSet to
+// parse), but a flag value must use "=" form, e.g. "--config=foo": a bare
+// "--config foo" cannot be told apart from the next command token.
+func findCommand(cmds Commands, args []string, parent *Command) (*Command, []string, error) {
+	name, index, leadingFlags := splitLeadingFlags(args)
+
+	if index == -1 {
 		return nil, nil, fmt.Errorf("%w, no command provided", ErrNotFound)
 	}
 
-	cmd := cmds.Search(args[0])
+	cmd := cmds.Search(name)
 
 	if cmd == nil {
-		return nil, nil, fmt.Errorf("%w, unknown command %q", ErrNotFound, args[0])
+		err := fmt.Errorf("%w, unknown command %q", ErrNotFound, name)
+
+		if parent == nil || !parent.DisableSuggestions {
+			if suggestions := suggestCommands(cmds, name); len(suggestions) > 0 {
+				err = &notFoundError{err: err, suggestions: suggestions}
+			}
+		}
+
+		return nil, nil, err
 	}
 
-	if len(args) > 1 && len(cmd.SubCommands) > 0 {
+	rest := args[index+1:]
+
+	if len(cmd.SubCommands) > 0 {
+		if subName, subIndex, _ := splitLeadingFlags(rest); subIndex != -1 && cmd.SubCommands.Search(subName) != nil {
 
-		subCmd, remainingArgs, err := findCommand(cmd.SubCommands, args[1:])
+			subCmd, remainingArgs, err := findCommand(cmd.SubCommands, rest, cmd)
 
-		if err == nil {
-			return subCmd, remainingArgs, nil
+			if err == nil {
+				return subCmd, append(append([]string{}, leadingFlags...), remainingArgs...), nil
+			}
 		}
 	}
 
-	return cmd, args[1:], nil
+	return cmd, append(append([]string{}, leadingFlags...), rest...), nil
+}
+
+// printSuggestions prints a "Did you mean" hint when err carries command
+// suggestions computed by findCommand.
+func printSuggestions(err error) {
+	var nf *notFoundError
+	if errors.As(err, &nf) && len(nf.suggestions) > 0 {
+		fmt.Fprintf(os.Stderr, "Did you mean: %s?\n", strings.Join(nf.suggestions, ", "))
+	}
 }
 
 func usage() {
 	progName := filepath.Base(os.Args[0])
 	data := struct {
-		Name     string
-		Short    string
-		Commands Commands
+		Name   string
+		Short  string
+		Groups []commandBucket
 	}{
-		Name:     progName,
-		Short:    "Command-line tool",
-		Commands: _commands,
+		Name:   progName,
+		Short:  "Command-line tool",
+		Groups: groupedCommands(_commands),
 	}
 	printUsage(os.Stderr, data)
 	os.Exit(2)
@@ -303,9 +469,10 @@ func help(args []string) {
 
 	name := args[0]
 
-	cmd, _, err := findCommand(_commands, args)
+	cmd, _, err := findCommand(_commands, args, nil)
 
 	if err != nil {
+		printSuggestions(err)
 		fatalf("help(%s): %v \n", name, err)
 	}
 
@@ -340,7 +507,12 @@ func setExitStatus(n int) {
 	_exitMu.Unlock()
 }
 
+// exit terminates the process, unless shell mode is active, in which case
+// it returns and the caller carries on, so the REPL session keeps running.
 func exit() {
+	if _shellMode {
+		return
+	}
 	os.Exit(_exitStatus)
 }
 
@@ -0,0 +1,298 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// ShellCompDirective tells the completion script how to post-process the
+// words returned by a ValidArgsFunction or FlagCompletionFunc.
+type ShellCompDirective int
+
+const (
+	// ShellCompDirectiveDefault lets the shell apply its normal file
+	// completion alongside the returned words.
+	ShellCompDirectiveDefault ShellCompDirective = 0
+
+	// ShellCompDirectiveError indicates completion failed and no words
+	// should be shown.
+	ShellCompDirectiveError ShellCompDirective = 1 << (iota - 1)
+
+	// ShellCompDirectiveNoSpace tells the shell not to add a trailing
+	// space after the completion.
+	ShellCompDirectiveNoSpace
+
+	// ShellCompDirectiveNoFileComp tells the shell not to fall back to
+	// file completion when no words are returned.
+	ShellCompDirectiveNoFileComp
+
+	// ShellCompDirectiveFilterDirs tells the shell to only complete
+	// directory names.
+	ShellCompDirectiveFilterDirs
+)
+
+// FlagCompletionFunc returns dynamic completions for a single flag's value.
+type FlagCompletionFunc func(cmd *Command, args []string, toComplete string) ([]string, ShellCompDirective)
+
+var (
+	_flagCompletionsMu sync.Mutex
+	_flagCompletions   = map[*Command]map[string]FlagCompletionFunc{}
+
+	_completionOnce sync.Once
+)
+
+// RegisterFlagCompletion associates a dynamic completion function with the
+// named flag on cmd, so bash/zsh/fish/PowerShell can offer values such as
+// file paths or remote resource names instead of just flag names.
+func RegisterFlagCompletion(cmd *Command, name string, fn FlagCompletionFunc) {
+	_flagCompletionsMu.Lock()
+	defer _flagCompletionsMu.Unlock()
+
+	m, ok := _flagCompletions[cmd]
+	if !ok {
+		m = map[string]FlagCompletionFunc{}
+		_flagCompletions[cmd] = m
+	}
+	m[name] = fn
+}
+
+func flagCompletion(cmd *Command, name string) FlagCompletionFunc {
+	_flagCompletionsMu.Lock()
+	defer _flagCompletionsMu.Unlock()
+
+	return _flagCompletions[cmd][name]
+}
+
+// registerCompletionCommand adds the built-in "completion" command the
+// first time Execute runs.
+func registerCompletionCommand() {
+	_completionOnce.Do(func() {
+		// __complete is registered at the top level, not under completion's
+		// SubCommands, because every generated script invokes it directly
+		// as "<prog> __complete ...".
+		AddCommands(completionCommand(), hiddenCompleteCommand())
+	})
+}
+
+func completionCommand() *Command {
+	return &Command{
+		Name:      "completion",
+		Short:     "Generate shell completion scripts",
+		UsageLine: "completion <bash|zsh|fish|powershell>",
+		Long:      "Generate a shell-completion script for the given shell, to be sourced from your shell's startup file.",
+		Group:     _completionCommandGroupID,
+		SubCommands: Commands{
+			{Name: "bash", Short: "Generate the bash completion script", Run: runCompletion(genBashCompletion)},
+			{Name: "zsh", Short: "Generate the zsh completion script", Run: runCompletion(genZshCompletion)},
+			{Name: "fish", Short: "Generate the fish completion script", Run: runCompletion(genFishCompletion)},
+			{Name: "powershell", Short: "Generate the PowerShell completion script", Run: runCompletion(genPowerShellCompletion)},
+		},
+	}
+}
+
+func runCompletion(gen func(w io.Writer, prog string) error) func(cmd *Command, args []string) error {
+	return func(cmd *Command, args []string) error {
+		return gen(os.Stdout, filepath.Base(os.Args[0]))
+	}
+}
+
+func hiddenCompleteCommand() *Command {
+	return &Command{
+		Name:   "__complete",
+		Hidden: true,
+		Run: func(cmd *Command, args []string) error {
+			toComplete := ""
+			if len(args) > 0 {
+				toComplete = args[len(args)-1]
+				args = args[:len(args)-1]
+			}
+
+			words, directive := complete(args, toComplete)
+
+			for _, w := range words {
+				fmt.Fprintln(os.Stdout, w)
+			}
+			fmt.Fprintf(os.Stdout, ":%d\n", directive)
+
+			return nil
+		},
+	}
+}
+
+// complete resolves the command identified by args and returns the
+// completions for toComplete, consulting ValidArgsFunction and the
+// per-flag completion registry along the way.
+func complete(args []string, toComplete string) ([]string, ShellCompDirective) {
+	cmd, remaining, err := findCommand(_commands, args, nil)
+
+	if err != nil {
+		return matchNames(completionNames(_commands), toComplete), ShellCompDirectiveNoFileComp
+	}
+
+	if strings.HasPrefix(toComplete, "-") {
+		return completeFlags(cmd, toComplete)
+	}
+
+	words := matchNames(completionNames(cmd.SubCommands), toComplete)
+
+	if cmd.ValidArgsFunction != nil {
+		extra, directive := cmd.ValidArgsFunction(cmd, remaining, toComplete)
+		return append(words, extra...), directive
+	}
+
+	return words, ShellCompDirectiveNoFileComp
+}
+
+func completionNames(cmds Commands) []string {
+	var names []string
+	for _, c := range cmds {
+		if c.Hidden {
+			continue
+		}
+		names = append(names, c.Name)
+		names = append(names, c.Aliases...)
+	}
+	return names
+}
+
+func matchNames(names []string, toComplete string) []string {
+	var words []string
+	for _, n := range names {
+		if strings.HasPrefix(n, toComplete) {
+			words = append(words, n)
+		}
+	}
+	return words
+}
+
+func completeFlags(cmd *Command, toComplete string) ([]string, ShellCompDirective) {
+	name := strings.TrimLeft(toComplete, "-")
+
+	if fn := flagCompletion(cmd, name); fn != nil {
+		return fn(cmd, nil, toComplete)
+	}
+
+	if cmd.flag == nil {
+		cmd.flag = flag.NewFlagSet(cmd.Name, flag.ContinueOnError)
+		addFlags(cmd.flag)
+		for _, ancestor := range cmd.ancestorChain() {
+			if ancestor.PersistentFlags != nil {
+				ancestor.PersistentFlags(cmd.flag)
+			}
+		}
+		if cmd.SetFlags != nil {
+			cmd.SetFlags(cmd.flag)
+		}
+	}
+
+	var words []string
+	cmd.flag.VisitAll(func(f *flag.Flag) {
+		if strings.HasPrefix(f.Name, name) {
+			words = append(words, "--"+f.Name)
+		}
+	})
+
+	return words, ShellCompDirectiveNoSpace
+}
+
+// The generated scripts decode the trailing ":<directive>" line emitted by
+// __complete and act on the ShellCompDirectiveFilterDirs bit, and (bash and
+// zsh only) the ShellCompDirectiveNoSpace bit; ShellCompDirectiveNoFileComp is implied
+// by always disabling the shell's own file completion, and
+// ShellCompDirectiveError just yields no candidates.
+
+func genBashCompletion(w io.Writer, prog string) error {
+	_, err := fmt.Fprintf(w, `# bash completion for %[1]s
+_%[1]s_complete() {
+  local words cur out directive
+  cur="${COMP_WORDS[COMP_CWORD]}"
+  words=("${COMP_WORDS[@]:1:COMP_CWORD-1}")
+  out=$(%[1]s __complete "${words[@]}" "$cur" 2>/dev/null)
+  directive=$(echo "$out" | tail -n1 | tr -d ':')
+  if (( (directive & 8) != 0 )); then
+    COMPREPLY=($(compgen -d -- "$cur"))
+  else
+    COMPREPLY=($(echo "$out" | sed '$d'))
+  fi
+  if (( (directive & 2) != 0 )); then
+    compopt -o nospace
+  fi
+}
+complete -F _%[1]s_complete %[1]s
+`, prog)
+	return err
+}
+
+func genZshCompletion(w io.Writer, prog string) error {
+	_, err := fmt.Fprintf(w, `#compdef %[1]s
+_%[1]s() {
+  local -a words reply
+  local cur out directive
+  cur="${words[-1]}"
+  out=$(%[1]s __complete "${words[@]:1:#words-1}" "$cur" 2>/dev/null)
+  directive=$(echo "$out" | tail -n1 | tr -d ':')
+  if (( (directive & 8) != 0 )); then
+    _path_files -/
+    return
+  fi
+  reply=(${(f)"$(echo "$out" | sed '$d')"})
+  if (( (directive & 2) != 0 )); then
+    compadd -S '' -a reply
+  else
+    compadd -a reply
+  fi
+}
+compdef _%[1]s %[1]s
+`, prog)
+	return err
+}
+
+func genFishCompletion(w io.Writer, prog string) error {
+	// fish's "complete" has no per-invocation way to suppress the trailing
+	// space it adds after a completion, so ShellCompDirectiveNoSpace is not
+	// honored here; ShellCompDirectiveFilterDirs is.
+	_, err := fmt.Fprintf(w, `function __%[1]s_complete
+  set -l tokens (commandline -opc)
+  set -l cur (commandline -ct)
+  set -l out (%[1]s __complete $tokens[2..-1] $cur 2>/dev/null)
+  set -l directive (string replace ':' '' -- $out[-1])
+  if test (math "$directive & 8") -ne 0
+    __fish_complete_directories $cur
+    return
+  end
+  for w in $out
+    if not string match -q ':*' -- $w
+      echo $w
+    end
+  end
+end
+complete -c %[1]s -f -a '(__%[1]s_complete)'
+`, prog)
+	return err
+}
+
+func genPowerShellCompletion(w io.Writer, prog string) error {
+	// PowerShell's CompletionResult has no equivalent of "no trailing
+	// space", so ShellCompDirectiveNoSpace is not honored here;
+	// ShellCompDirectiveFilterDirs is.
+	_, err := fmt.Fprintf(w, `Register-ArgumentCompleter -Native -CommandName %[1]s -ScriptBlock {
+    param($wordToComplete, $commandAst, $cursorPosition)
+    $tokens = $commandAst.CommandElements[1..($commandAst.CommandElements.Count - 1)] | ForEach-Object { $_.ToString() }
+    $out = & %[1]s __complete @tokens $wordToComplete 2>$null
+    $directive = [int]($out[-1] -replace '^:', '')
+    if ($directive -band 8) {
+        Get-ChildItem -Directory -Name "$wordToComplete*" |
+            ForEach-Object { [System.Management.Automation.CompletionResult]::new($_, $_, 'ParameterValue', $_) }
+        return
+    }
+    $out | Where-Object { $_ -notmatch '^:' } |
+        ForEach-Object { [System.Management.Automation.CompletionResult]::new($_, $_, 'ParameterValue', $_) }
+}
+`, prog)
+	return err
+}
@@ -0,0 +1,19 @@
+package cmd
+
+import "testing"
+
+func TestCompleteCommandIsTopLevel(t *testing.T) {
+	registerCompletionCommand()
+
+	cmd, _, err := findCommand(_commands, []string{"__complete", ""}, nil)
+	if err != nil {
+		t.Fatalf("findCommand(__complete): %v", err)
+	}
+	if cmd.Name != "__complete" {
+		t.Fatalf("got command %q, want __complete", cmd.Name)
+	}
+
+	if sub, _, err := findCommand(_commands, []string{"completion", "__complete", ""}, nil); err == nil && sub.Name == "__complete" {
+		t.Fatalf("completion __complete unexpectedly resolved; __complete must not live under completion's SubCommands")
+	}
+}
@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"fmt"
+	"sync"
+)
+
+type commandGroup struct {
+	id    string
+	title string
+}
+
+var (
+	_groups                   []commandGroup
+	_helpCommandGroupID       string
+	_completionCommandGroupID string
+
+	_helpOnce sync.Once
+)
+
+// AddGroup registers a named bucket that commands can opt into via their
+// Group field, used to render categorized help output for large command
+// sets. Groups are rendered in the order they are added.
+func AddGroup(id, title string) {
+	_groups = append(_groups, commandGroup{id: id, title: title})
+}
+
+// SetHelpCommandGroupID assigns the built-in "help" command to the group
+// registered under id.
+func SetHelpCommandGroupID(id string) {
+	_helpCommandGroupID = id
+}
+
+// SetCompletionCommandGroupID assigns the built-in "completion" command to
+// the group registered under id.
+func SetCompletionCommandGroupID(id string) {
+	_completionCommandGroupID = id
+}
+
+// commandBucket is a titled group of commands for rendering in Usage.
+type commandBucket struct {
+	Title    string
+	Commands Commands
+}
+
+// groupedCommands buckets cmds by their Group field, in the order groups
+// were registered with AddGroup, followed by a catch-all "Additional
+// Commands" bucket for commands with an empty or unregistered Group.
+func groupedCommands(cmds Commands) []commandBucket {
+	titleByID := map[string]string{}
+	for _, g := range _groups {
+		titleByID[g.id] = g.title
+	}
+
+	byID := map[string]*commandBucket{}
+
+	for _, c := range cmds {
+		if !c.Runnable() || c.Hidden {
+			continue
+		}
+
+		id := c.Group
+		title, known := titleByID[id]
+		if id == "" || !known {
+			id = ""
+			title = "Additional Commands"
+		}
+
+		b, ok := byID[id]
+		if !ok {
+			b = &commandBucket{Title: title}
+			byID[id] = b
+		}
+		b.Commands = append(b.Commands, c)
+	}
+
+	var result []commandBucket
+	for _, g := range _groups {
+		if b, ok := byID[g.id]; ok {
+			result = append(result, *b)
+		}
+	}
+	if b, ok := byID[""]; ok {
+		result = append(result, *b)
+	}
+
+	return result
+}
+
+// registerHelpCommand adds a visible "help" command to the tree so it
+// participates in grouped Usage output and Shell dispatch, alongside the
+// fast-path special case in Execute.
+func registerHelpCommand() {
+	_helpOnce.Do(func() {
+		AddCommands(&Command{
+			Name:  "help",
+			Short: "Show help for a command",
+			Group: _helpCommandGroupID,
+			Run: func(cmd *Command, args []string) error {
+				if len(args) == 0 {
+					help(args)
+					return nil
+				}
+				// Look the command up here rather than in help: in shell
+				// mode help's fatalf returns and help would go on to call
+				// Usage on a nil command.
+				target, _, err := findCommand(_commands, args, nil)
+				if err != nil {
+					printSuggestions(err)
+					return fmt.Errorf("help(%s): %w", args[0], err)
+				}
+				target.Usage()
+				return nil
+			},
+		})
+	})
+}
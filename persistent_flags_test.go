@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"flag"
+	"testing"
+)
+
+// TestPersistentFlagsAfterSubcommand exercises the documented example from
+// the PersistentFlags doc comment: "mycli sub --config=foo bar --baz". The
+// flag belongs to "sub" but is placed between "sub" and its own subcommand
+// "bar"; findCommand must resolve through it to "bar" instead of returning
+// the non-runnable "sub", which runCommand would reject with an error.
+func TestPersistentFlagsAfterSubcommand(t *testing.T) {
+	var config string
+	var baz bool
+
+	bar := &Command{
+		Name: "bar",
+		Run: func(cmd *Command, args []string) error {
+			baz = cmd.flag.Lookup("baz").Value.(flag.Getter).Get().(bool)
+			return nil
+		},
+		SetFlags: func(f *flag.FlagSet) {
+			f.Bool("baz", false, "baz flag")
+		},
+	}
+
+	sub := &Command{
+		Name:        "persist-sub",
+		SubCommands: Commands{bar},
+		PersistentFlags: func(f *flag.FlagSet) {
+			f.StringVar(&config, "config", "", "config path")
+		},
+	}
+
+	AddCommands(sub)
+
+	cmd, remainingArgs, err := findCommand(_commands, []string{"persist-sub", "--config=foo", "bar", "--baz"}, nil)
+	if err != nil {
+		t.Fatalf("findCommand: %v", err)
+	}
+	if cmd != bar {
+		t.Fatalf("resolved %q, want %q", cmd.Name, bar.Name)
+	}
+
+	cmd.flag = flag.NewFlagSet(cmd.Name, flag.ContinueOnError)
+	for _, ancestor := range cmd.ancestorChain() {
+		if ancestor.PersistentFlags != nil {
+			ancestor.PersistentFlags(cmd.flag)
+		}
+	}
+	if cmd.SetFlags != nil {
+		cmd.SetFlags(cmd.flag)
+	}
+
+	if err := cmd.flag.Parse(remainingArgs); err != nil {
+		t.Fatalf("Parse(%v): %v", remainingArgs, err)
+	}
+
+	if err := runCommand(cmd, cmd.flag.Args()); err != nil {
+		t.Fatalf("runCommand: %v", err)
+	}
+
+	if config != "foo" {
+		t.Errorf("config = %q, want %q", config, "foo")
+	}
+	if !baz {
+		t.Errorf("baz = false, want true")
+	}
+}
@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// _shellMode is set while the REPL runs and makes exit (and so fatalf)
+// return instead of terminating the process.
+var _shellMode bool
+
+var _shellOnce sync.Once
+
+// registerShellCommand adds the built-in "shell" command the first time
+// Execute runs.
+func registerShellCommand() {
+	_shellOnce.Do(func() {
+		AddCommands(shellCommand())
+	})
+}
+
+func shellCommand() *Command {
+	return &Command{
+		Name:  "shell",
+		Short: "Start an interactive shell",
+		Run: func(cmd *Command, args []string) error {
+			runShell(os.Stdin, os.Stdout)
+			return nil
+		},
+	}
+}
+
+// Shell drops the user into an interactive REPL over the registered command
+// tree: each line is tokenized and dispatched via findCommand, without
+// re-entering os.Exit on error. History is file-backed under
+// $XDG_STATE_HOME; "exit", "quit" or EOF ends the session.
+//
+// Tab completion is not implemented: the REPL reads lines with a plain
+// bufio.Scanner, which has no hook for individual keystrokes, so there is
+// nowhere to intercept Tab and drive it through the completion registry.
+// That would require raw-mode terminal input, which this package does not
+// provide today.
+func (c *Command) Shell() {
+	runShell(os.Stdin, os.Stdout)
+}
+
+func runShell(in io.Reader, out io.Writer) {
+	_shellMode = true
+	defer func() { _shellMode = false }()
+
+	historyPath := shellHistoryPath()
+	history := loadShellHistory(historyPath)
+
+	scanner := bufio.NewScanner(in)
+	prog := filepath.Base(os.Args[0])
+
+	for {
+		fmt.Fprintf(out, "%s> ", prog)
+
+		if !scanner.Scan() {
+			fmt.Fprintln(out)
+			break
+		}
+
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" {
+			continue
+		}
+
+		if line == "exit" || line == "quit" {
+			break
+		}
+
+		history = append(history, line)
+
+		if err := runShellLine(out, line); err != nil {
+			fmt.Fprintln(out, err)
+		}
+	}
+
+	saveShellHistory(historyPath, history)
+}
+
+func runShellLine(out io.Writer, line string) error {
+	args := strings.Fields(line)
+
+	cmd, remainingArgs, err := findCommand(_commands, args, nil)
+
+	if err != nil {
+		printSuggestions(err)
+		return fmt.Errorf("cmd(%s): %w", args[0], err)
+	}
+
+	// Always build a fresh FlagSet: cmd.flag is shared with Execute's
+	// single-shot invocation, and a command can be dispatched many times in
+	// one shell session, so reusing it would panic on the second
+	// registration of any flag.
+	cmd.flag = flag.NewFlagSet(cmd.Name, flag.ContinueOnError)
+
+	addFlags(cmd.flag)
+
+	for _, ancestor := range cmd.ancestorChain() {
+		if ancestor.PersistentFlags != nil {
+			ancestor.PersistentFlags(cmd.flag)
+		}
+	}
+
+	if cmd.SetFlags != nil {
+		cmd.SetFlags(cmd.flag)
+	}
+
+	cmd.flag.Usage = func() {
+		cmd.Usage()
+	}
+
+	if err := cmd.flag.Parse(remainingArgs); err != nil {
+		return fmt.Errorf("cmd(%s): %w", cmd.Name, err)
+	}
+
+	if err := runCommand(cmd, cmd.flag.Args()); err != nil {
+		return fmt.Errorf("cmd(%s): %w", cmd.Name, err)
+	}
+
+	return nil
+}
+
+func shellHistoryPath() string {
+	dir := os.Getenv("XDG_STATE_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".local", "state")
+	}
+
+	return filepath.Join(dir, filepath.Base(os.Args[0]), "history")
+}
+
+func loadShellHistory(path string) []string {
+	if path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+
+	var history []string
+	for _, line := range strings.Split(strings.TrimRight(string(data), "\n"), "\n") {
+		if line != "" {
+			history = append(history, line)
+		}
+	}
+
+	return history
+}
+
+const _maxShellHistory = 1000
+
+func saveShellHistory(path string, history []string) {
+	if path == "" || len(history) == 0 {
+		return
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return
+	}
+
+	if len(history) > _maxShellHistory {
+		history = history[len(history)-_maxShellHistory:]
+	}
+
+	_ = os.WriteFile(path, []byte(strings.Join(history, "\n")+"\n"), 0o644)
+}
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"bytes"
+	"flag"
+	"testing"
+)
+
+func TestRunShellLineReusesCommandAcrossInvocations(t *testing.T) {
+	var got string
+
+	cmd := &Command{
+		Name: "greet",
+		Run: func(cmd *Command, args []string) error {
+			got = cmd.flag.Lookup("name").Value.String()
+			return nil
+		},
+		SetFlags: func(f *flag.FlagSet) {
+			f.String("name", "", "name to greet")
+		},
+	}
+
+	AddCommands(cmd)
+
+	var out bytes.Buffer
+
+	if err := runShellLine(&out, "greet --name=a"); err != nil {
+		t.Fatalf("first invocation: %v", err)
+	}
+	if got != "a" {
+		t.Fatalf("got %q, want %q", got, "a")
+	}
+
+	if err := runShellLine(&out, "greet --name=b"); err != nil {
+		t.Fatalf("second invocation: %v", err)
+	}
+	if got != "b" {
+		t.Fatalf("got %q, want %q", got, "b")
+	}
+}
@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RegisterStruct walks the fields of the struct pointed to by v and
+// registers a flag on cmd for each field carrying a `flag:"name[,shorthand]"`
+// struct tag, reading `usage`, `default` and `env` tags alongside it. It is
+// a shorthand for writing the equivalent SetFlags closure by hand, e.g.:
+//
+//	type BackupOpts struct {
+//		Parent string `flag:"parent,p" usage:"use this parent snapshot" env:"MYAPP_PARENT"`
+//	}
+func RegisterStruct(cmd *Command, v interface{}) {
+	prev := cmd.SetFlags
+	cmd.SetFlags = func(f *flag.FlagSet) {
+		if prev != nil {
+			prev(f)
+		}
+		registerStructFlags(f, v)
+	}
+}
+
+func registerStructFlags(f *flag.FlagSet, v interface{}) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		panic("cmd: RegisterStruct requires a pointer to a struct")
+	}
+
+	rv = rv.Elem()
+	rt := rv.Type()
+
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+
+		tag, ok := field.Tag.Lookup("flag")
+		if !ok {
+			continue
+		}
+
+		name, short := parseFlagTag(tag)
+		usage := field.Tag.Get("usage")
+		def := field.Tag.Get("default")
+
+		if env := field.Tag.Get("env"); env != "" {
+			if v, ok := os.LookupEnv(env); ok {
+				def = v
+			}
+			usage = fmt.Sprintf("%s (env: %s)", usage, env)
+		}
+
+		if def != "" {
+			usage = fmt.Sprintf("%s (default: %s)", usage, def)
+		}
+
+		registerField(f, rv.Field(i), name, short, usage, def)
+	}
+}
+
+func parseFlagTag(tag string) (name, short string) {
+	parts := strings.SplitN(tag, ",", 2)
+	name = parts[0]
+	if len(parts) > 1 {
+		short = parts[1]
+	}
+	return
+}
+
+func registerField(f *flag.FlagSet, fv reflect.Value, name, short, usage, def string) {
+	addr := fv.Addr().Interface()
+
+	if fval, ok := addr.(flag.Value); ok {
+		if def != "" {
+			fval.Set(def)
+		}
+		f.Var(fval, name, usage)
+		if short != "" {
+			f.Var(fval, short, usage)
+		}
+		return
+	}
+
+	switch {
+	case fv.Type() == reflect.TypeOf(time.Duration(0)):
+		d, _ := time.ParseDuration(def)
+		f.DurationVar(addr.(*time.Duration), name, d, usage)
+		if short != "" {
+			f.DurationVar(addr.(*time.Duration), short, d, usage)
+		}
+
+	case fv.Kind() == reflect.String:
+		f.StringVar(addr.(*string), name, def, usage)
+		if short != "" {
+			f.StringVar(addr.(*string), short, def, usage)
+		}
+
+	case fv.Kind() == reflect.Bool:
+		b, _ := strconv.ParseBool(def)
+		f.BoolVar(addr.(*bool), name, b, usage)
+		if short != "" {
+			f.BoolVar(addr.(*bool), short, b, usage)
+		}
+
+	case fv.Kind() == reflect.Int:
+		n, _ := strconv.Atoi(def)
+		f.IntVar(addr.(*int), name, n, usage)
+		if short != "" {
+			f.IntVar(addr.(*int), short, n, usage)
+		}
+
+	case fv.Kind() == reflect.Int64:
+		n, _ := strconv.ParseInt(def, 10, 64)
+		f.Int64Var(addr.(*int64), name, n, usage)
+		if short != "" {
+			f.Int64Var(addr.(*int64), short, n, usage)
+		}
+
+	case fv.Kind() == reflect.Float64:
+		n, _ := strconv.ParseFloat(def, 64)
+		f.Float64Var(addr.(*float64), name, n, usage)
+		if short != "" {
+			f.Float64Var(addr.(*float64), short, n, usage)
+		}
+
+	case fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() == reflect.String:
+		val := newStringSliceValue(addr.(*[]string), def)
+		f.Var(val, name, usage)
+		if short != "" {
+			f.Var(val, short, usage)
+		}
+
+	default:
+		panic(fmt.Sprintf("cmd: RegisterStruct: unsupported field type %s", fv.Type()))
+	}
+}
+
+// stringSliceValue implements flag.Value to support repeatable []string
+// flags, e.g. `--tag=a --tag=b`.
+type stringSliceValue struct {
+	target *[]string
+}
+
+func newStringSliceValue(target *[]string, def string) *stringSliceValue {
+	if def != "" {
+		*target = append(*target, strings.Split(def, ",")...)
+	}
+	return &stringSliceValue{target: target}
+}
+
+func (s *stringSliceValue) String() string {
+	if s == nil || s.target == nil {
+		return ""
+	}
+	return strings.Join(*s.target, ",")
+}
+
+func (s *stringSliceValue) Set(v string) error {
+	*s.target = append(*s.target, v)
+	return nil
+}
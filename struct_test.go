@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"flag"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestRegisterStructShowsDefaultAndEnvInUsage(t *testing.T) {
+	os.Setenv("CMD_TEST_PARENT", "from-env")
+	defer os.Unsetenv("CMD_TEST_PARENT")
+
+	type opts struct {
+		Parent string `flag:"parent,p" usage:"use this parent snapshot" default:"latest" env:"CMD_TEST_PARENT"`
+	}
+
+	var o opts
+	f := flag.NewFlagSet("test", flag.ContinueOnError)
+	registerStructFlags(f, &o)
+
+	fl := f.Lookup("parent")
+	if fl == nil {
+		t.Fatal("flag \"parent\" was not registered")
+	}
+	if !strings.Contains(fl.Usage, "env: CMD_TEST_PARENT") {
+		t.Errorf("usage %q missing env var name", fl.Usage)
+	}
+	if !strings.Contains(fl.Usage, "default: from-env") {
+		t.Errorf("usage %q missing resolved default", fl.Usage)
+	}
+}
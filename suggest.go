@@ -0,0 +1,125 @@
+package cmd
+
+import "sort"
+
+// notFoundError wraps ErrNotFound with the command suggestions computed for
+// the unmatched token, if any.
+type notFoundError struct {
+	err         error
+	suggestions []string
+}
+
+func (e *notFoundError) Error() string {
+	return e.err.Error()
+}
+
+func (e *notFoundError) Unwrap() error {
+	return e.err
+}
+
+// _maxSuggestions caps how many "Did you mean" candidates are shown.
+const _maxSuggestions = 3
+
+// _suggestionsMinimumDistance overrides the length-scaled default distance
+// threshold when set via SetSuggestionsMinimumDistance. -1 means unset.
+var _suggestionsMinimumDistance = -1
+
+// SetSuggestionsMinimumDistance sets a fixed Levenshtein distance threshold
+// for "Did you mean" suggestions, overriding the default which scales with
+// the length of the mistyped token.
+func SetSuggestionsMinimumDistance(d int) {
+	_suggestionsMinimumDistance = d
+}
+
+func suggestionThreshold(token string) int {
+	if _suggestionsMinimumDistance >= 0 {
+		return _suggestionsMinimumDistance
+	}
+	if len(token) >= 4 {
+		return 2
+	}
+	return 1
+}
+
+type suggestion struct {
+	name string
+	dist int
+}
+
+// suggestCommands returns the names and aliases in cmds closest to token,
+// at or below the applicable distance threshold, sorted by distance then name.
+func suggestCommands(cmds Commands, token string) []string {
+	threshold := suggestionThreshold(token)
+
+	var candidates []suggestion
+
+	for _, c := range cmds {
+		if c.Hidden {
+			continue
+		}
+
+		names := append([]string{c.Name}, c.Aliases...)
+
+		for _, n := range names {
+			if d := levenshtein(token, n); d <= threshold {
+				candidates = append(candidates, suggestion{name: n, dist: d})
+			}
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].dist != candidates[j].dist {
+			return candidates[i].dist < candidates[j].dist
+		}
+		return candidates[i].name < candidates[j].name
+	})
+
+	if len(candidates) > _maxSuggestions {
+		candidates = candidates[:_maxSuggestions]
+	}
+
+	names := make([]string, len(candidates))
+	for i, s := range candidates {
+		names[i] = s.name
+	}
+
+	return names
+}
+
+// levenshtein computes the edit distance between a and b using the classic
+// DP recurrence over a (len(a)+1) x (len(b)+1) table, with insert, delete
+// and substitute costs of 1.
+func levenshtein(a, b string) int {
+	la, lb := len(a), len(b)
+
+	d := make([][]int, la+1)
+	for i := range d {
+		d[i] = make([]int, lb+1)
+		d[i][0] = i
+	}
+	for j := 0; j <= lb; j++ {
+		d[0][j] = j
+	}
+
+	for i := 1; i <= la; i++ {
+		for j := 1; j <= lb; j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d[i][j] = min3(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+		}
+	}
+
+	return d[la][lb]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}